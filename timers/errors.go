@@ -0,0 +1,80 @@
+package timers
+
+import "fmt"
+
+/* Typed errors returned by the E-variants of the functions in this package.
+The struct types can be inspected with errors.As, and the sentinel
+values (ErrNoLogFile, ErrNoFileTimerCollection) with errors.Is; errors that wrap an
+underlying cause (a missing log file, a truncated record) support
+errors.Is/errors.As against that cause as well. */
+
+// ErrTimerAlreadyRunning is returned when starting a timer that has
+// already been started.
+type ErrTimerAlreadyRunning struct {
+	Name string
+}
+
+func (e *ErrTimerAlreadyRunning) Error() string {
+	return fmt.Sprintf("Attempted to start running timer %s", e.Name)
+}
+
+// ErrTimerAlreadyEnded is returned when ending a timer that has already
+// been ended.
+type ErrTimerAlreadyEnded struct {
+	Name string
+}
+
+func (e *ErrTimerAlreadyEnded) Error() string {
+	return fmt.Sprintf("Attempted to end stopped timer %s", e.Name)
+}
+
+// ErrTimerNotStarted is returned when resetting, polling, stopping, or
+// ending a timer that was never started.
+type ErrTimerNotStarted struct {
+	Name string
+	Verb string
+}
+
+func (e *ErrTimerNotStarted) Error() string {
+	return fmt.Sprintf("Attempted to %s timer %s, which is not running", e.Verb, e.Name)
+}
+
+// ErrNoLogFile is returned when closing a log file while none is active.
+var ErrNoLogFile = fmt.Errorf("Attempted to close log file, but not log file is active")
+
+// ErrInvalidTimerDir is returned when a FileTimerCollection is pointed
+// at a path that is not a directory.
+type ErrInvalidTimerDir struct {
+	Path string
+}
+
+func (e *ErrInvalidTimerDir) Error() string {
+	return fmt.Sprintf("Attempted to set Timer collection to invalid directory %s", e.Path)
+}
+
+// ErrNoFileTimerCollection is returned by the package-level file timer
+// functions when no collection has been installed with
+// SetFileTimerCollection/SetFileTimerCollectionE yet.
+var ErrNoFileTimerCollection = fmt.Errorf("Attempted to use file timers, but no FileTimerCollection is set")
+
+// ErrCorruptLog is returned by the log parser when a record is
+// malformed or truncated partway through. Cause is the underlying read
+// error (e.g. io.ErrUnexpectedEOF) when the corruption was detected via
+// a failed read, and nil when it was detected some other way (e.g. a
+// checksum mismatch).
+type ErrCorruptLog struct {
+	Offset int64
+	Reason string
+	Cause  error
+}
+
+func (e *ErrCorruptLog) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("corrupt timer log at offset %d: %s: %s", e.Offset, e.Reason, e.Cause)
+	}
+	return fmt.Sprintf("corrupt timer log at offset %d: %s", e.Offset, e.Reason)
+}
+
+func (e *ErrCorruptLog) Unwrap() error {
+	return e.Cause
+}
@@ -0,0 +1,133 @@
+package timers
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestTimerRegistryEVariantsReturnTypedErrors(t *testing.T) {
+	r := NewTimerRegistry()
+
+	if err := r.StartTimerE("t"); err != nil {
+		t.Fatalf("StartTimerE: unexpected error %v", err)
+	}
+	var alreadyRunning *ErrTimerAlreadyRunning
+	if err := r.StartTimerE("t"); !errors.As(err, &alreadyRunning) {
+		t.Fatalf("StartTimerE on running timer = %v, want *ErrTimerAlreadyRunning", err)
+	}
+
+	if err := r.EndTimerE("t"); err != nil {
+		t.Fatalf("EndTimerE: unexpected error %v", err)
+	}
+	var alreadyEnded *ErrTimerAlreadyEnded
+	if err := r.EndTimerE("t"); !errors.As(err, &alreadyEnded) {
+		t.Fatalf("EndTimerE on ended timer = %v, want *ErrTimerAlreadyEnded", err)
+	}
+
+	var notStarted *ErrTimerNotStarted
+	if _, err := r.PollTimerE("never-started"); !errors.As(err, &notStarted) {
+		t.Fatalf("PollTimerE on unstarted timer = %v, want *ErrTimerNotStarted", err)
+	}
+}
+
+// TestFileTimerWrappersWithoutCollection covers the maintainer's
+// reported regression: calling a package-level file timer E-variant
+// before SetFileTimerCollection/SetFileTimerCollectionE must return
+// ErrNoFileTimerCollection, not panic with a nil pointer dereference.
+func TestFileTimerWrappersWithoutCollection(t *testing.T) {
+	old := loadDefaultFileTimerCollection()
+	defaultFileTimerCollection.Store(&fileTimerCollectionBox{})
+	defer defaultFileTimerCollection.Store(&fileTimerCollectionBox{collection: old})
+
+	if err := StartFileTimerE("foo"); !errors.Is(err, ErrNoFileTimerCollection) {
+		t.Errorf("StartFileTimerE = %v, want ErrNoFileTimerCollection", err)
+	}
+	if err := EndFileTimerE("foo"); !errors.Is(err, ErrNoFileTimerCollection) {
+		t.Errorf("EndFileTimerE = %v, want ErrNoFileTimerCollection", err)
+	}
+	if _, err := GetFileTimerDeltaE("foo"); !errors.Is(err, ErrNoFileTimerCollection) {
+		t.Errorf("GetFileTimerDeltaE = %v, want ErrNoFileTimerCollection", err)
+	}
+	if _, err := PollFileTimerE("foo"); !errors.Is(err, ErrNoFileTimerCollection) {
+		t.Errorf("PollFileTimerE = %v, want ErrNoFileTimerCollection", err)
+	}
+	if err := DeleteFileTimerE("foo"); !errors.Is(err, ErrNoFileTimerCollection) {
+		t.Errorf("DeleteFileTimerE = %v, want ErrNoFileTimerCollection", err)
+	}
+
+	// The non-E variants still panic, but with the descriptive error
+	// rather than a bare nil pointer dereference.
+	assertPanicsWith(t, "StartFileTimer", ErrNoFileTimerCollection, func() { StartFileTimer("foo") })
+	assertPanicsWith(t, "GetFileTimerDelta", ErrNoFileTimerCollection, func() { GetFileTimerDelta("foo") })
+
+	// DeleteFileTimerIfExists has no error return at all; it must be a
+	// silent no-op rather than a panic.
+	DeleteFileTimerIfExists("foo")
+}
+
+func assertPanicsWith(t *testing.T, name string, want error, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("%s: expected panic, got none", name)
+			return
+		}
+		err, ok := r.(error)
+		if !ok || !errors.Is(err, want) {
+			t.Errorf("%s: panicked with %v, want %v", name, r, want)
+		}
+	}()
+	fn()
+}
+
+// TestFileTimerCollectionWrapsFileErrors covers the maintainer's
+// reported gap: GetDeltaE and PollE must wrap the underlying
+// *os.PathError from a missing timer file, not just a generic error, so
+// callers can errors.Is/errors.As against the cause.
+func TestFileTimerCollectionWrapsFileErrors(t *testing.T) {
+	c := NewFileTimerCollection(t.TempDir())
+
+	if _, err := c.GetDeltaE("never-started"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetDeltaE on missing timer = %v, want wrapped os.ErrNotExist", err)
+	} else {
+		var pathErr *os.PathError
+		if !errors.As(err, &pathErr) {
+			t.Errorf("GetDeltaE on missing timer = %v, want errors.As to find *os.PathError", err)
+		}
+	}
+
+	if _, err := c.PollE("never-started"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("PollE on missing timer = %v, want wrapped os.ErrNotExist", err)
+	}
+
+	if err := c.StartTimerE("t"); err != nil {
+		t.Fatalf("StartTimerE: %v", err)
+	}
+	if _, err := c.GetDeltaE("t"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GetDeltaE with no end timer = %v, want wrapped os.ErrNotExist from the missing end file", err)
+	}
+
+	if err := c.DeleteE("never-started"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("DeleteE on missing timer = %v, want wrapped os.ErrNotExist", err)
+	}
+}
+
+func TestErrCorruptLogUnwrapsCause(t *testing.T) {
+	err := &ErrCorruptLog{Offset: 42, Reason: "truncated record name", Cause: io.ErrUnexpectedEOF}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(err, io.ErrUnexpectedEOF) = false, want true")
+	}
+
+	var pathErr *ErrCorruptLog
+	if !errors.As(err, &pathErr) {
+		t.Errorf("errors.As(err, &ErrCorruptLog{}) = false, want true")
+	}
+
+	noCause := &ErrCorruptLog{Offset: 0, Reason: "checksum mismatch"}
+	if errors.Is(noCause, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is with nil Cause unexpectedly matched io.ErrUnexpectedEOF")
+	}
+}
@@ -0,0 +1,68 @@
+package timers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Format selects the on-disk layout written by SetLogFile and
+// recognized by LogEventReader.
+type Format int
+
+const (
+	// FormatV1 is the original bare record stream: no header, no
+	// framing, wall-clock timestamps only.
+	FormatV1 Format = iota
+	// FormatV2 adds a file header, length-prefixed framing, a
+	// per-record CRC32C, and a monotonic timestamp alongside the
+	// wall-clock one.
+	FormatV2
+)
+
+// magicV2 identifies a FormatV2 log file. It is 8 bytes so that, together
+// with the version, flags, and reserved fields below, the header is
+// exactly 16 bytes.
+var magicV2 = [8]byte{'G', 'O', 'T', 'I', 'M', 'E', 'R', 'S'}
+
+const v2HeaderSize = 16
+
+const v2Version uint16 = 2
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func writeV2Header(w io.Writer) error {
+	var header [v2HeaderSize]byte
+	copy(header[0:8], magicV2[:])
+	binary.LittleEndian.PutUint16(header[8:10], v2Version)
+	binary.LittleEndian.PutUint16(header[10:12], 0) // flags
+	binary.LittleEndian.PutUint32(header[12:16], 0) // reserved
+	_, err := w.Write(header[:])
+	return err
+}
+
+// writeV2Record encodes name/kind plus the current wall-clock and
+// monotonic-since-start timestamps as one framed, CRC32C-checked
+// FormatV2 record.
+func writeV2Record(w io.Writer, name string, kind byte, logStart time.Time) error {
+	wallNs := time.Now().UnixNano()
+	monoNs := time.Since(logStart).Nanoseconds()
+
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(name)))
+
+	var buf bytes.Buffer
+	buf.Write(lenBuf[:n])
+	buf.WriteString(name)
+	buf.WriteByte(kind)
+	binary.Write(&buf, binary.LittleEndian, wallNs)
+	binary.Write(&buf, binary.LittleEndian, monoNs)
+
+	crc := crc32.Checksum(buf.Bytes(), crc32cTable)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, crc)
+}
@@ -0,0 +1,127 @@
+package timers
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamParseRoundTripV2(t *testing.T) {
+	path := writeTempLog(t, FormatV2, func(r *TimerRegistry) {
+		r.StartLogTimer("request")
+		r.EndLogTimer("request")
+	})
+
+	var events []LogEvent
+	if err := StreamParse([]string{path}, func(e LogEvent) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("StreamParse: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for _, e := range events {
+		if e.Format != FormatV2 {
+			t.Errorf("event.Format = %v, want FormatV2", e.Format)
+		}
+		if e.Name != "request" {
+			t.Errorf("event.Name = %q, want %q", e.Name, "request")
+		}
+		if e.MonoNs < 0 {
+			t.Errorf("event.MonoNs = %d, want >= 0", e.MonoNs)
+		}
+	}
+	if events[0].Kind != START_SYMBOL[0] || events[1].Kind != END_SYMBOL[0] {
+		t.Errorf("events = %+v, want start then end", events)
+	}
+	if events[1].MonoNs < events[0].MonoNs {
+		t.Errorf("end MonoNs %d before start MonoNs %d", events[1].MonoNs, events[0].MonoNs)
+	}
+}
+
+func TestStreamParseV2ChecksumMismatch(t *testing.T) {
+	path := writeTempLog(t, FormatV2, func(r *TimerRegistry) {
+		r.StartLogTimer("request")
+	})
+
+	// Flip a byte inside the record body (after the header) so the
+	// trailing CRC no longer matches.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[v2HeaderSize+2] ^= 0xFF
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = StreamParse([]string{path}, func(LogEvent) error { return nil })
+	var corrupt *ErrCorruptLog
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("StreamParse on tampered v2 log = %v, want *ErrCorruptLog", err)
+	}
+	if corrupt.Reason != "checksum mismatch" {
+		t.Errorf("corrupt.Reason = %q, want %q", corrupt.Reason, "checksum mismatch")
+	}
+}
+
+func TestStreamParseV2TruncatedRecordWrapsCause(t *testing.T) {
+	path := writeTempLog(t, FormatV2, func(r *TimerRegistry) {
+		r.StartLogTimer("request")
+	})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	err = StreamParse([]string{path}, func(LogEvent) error { return nil })
+	var corrupt *ErrCorruptLog
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("StreamParse on truncated v2 log = %v, want *ErrCorruptLog", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("errors.Is(err, io.ErrUnexpectedEOF) = false, want true (Cause = %v)", corrupt.Cause)
+	}
+}
+
+func TestLogEventReaderDetectsFormatFromMagic(t *testing.T) {
+	v2Path := writeTempLog(t, FormatV2, func(r *TimerRegistry) {
+		r.StartLogTimer("x")
+		r.EndLogTimer("x")
+	})
+	f, err := os.Open(v2Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	reader := NewLogEventReader(f)
+	event, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if event.Format != FormatV2 {
+		t.Errorf("auto-detected format = %v, want FormatV2", event.Format)
+	}
+
+	emptyPath := filepath.Join(t.TempDir(), "empty.log")
+	if err := os.WriteFile(emptyPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ef, err := os.Open(emptyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ef.Close()
+	if _, err := NewLogEventReader(ef).Next(); err != io.EOF {
+		t.Errorf("Next on empty file = %v, want io.EOF", err)
+	}
+}
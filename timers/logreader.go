@@ -0,0 +1,204 @@
+package timers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// LogEvent is a single start or end record read from a timer log file.
+// MonoNs is only populated for records read from a FormatV2 log; it is
+// zero for FormatV1, which has no monotonic timestamp.
+type LogEvent struct {
+	Name   string
+	Kind   byte // START_SYMBOL[0] or END_SYMBOL[0]
+	TimeNs int64
+	MonoNs int64
+	Format Format
+}
+
+// LogEventReader reads LogEvents one at a time from a timer log,
+// without ever buffering the whole file in memory. It auto-detects
+// FormatV1 vs. FormatV2 from the first bytes of the stream.
+type LogEventReader struct {
+	r        *bufio.Reader
+	offset   int64
+	format   Format
+	detected bool
+}
+
+// NewLogEventReader wraps r in a LogEventReader.
+func NewLogEventReader(r io.Reader) *LogEventReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &LogEventReader{r: br}
+}
+
+func (l *LogEventReader) detectFormat() error {
+	if l.detected {
+		return nil
+	}
+	l.detected = true
+	peek, err := l.r.Peek(len(magicV2))
+	if err != nil {
+		// Fewer bytes than the magic than are available; too short to be a
+		// FormatV2 header, so treat the stream as FormatV1 (e.g. empty file).
+		l.format = FormatV1
+		return nil
+	}
+	if !bytes.Equal(peek, magicV2[:]) {
+		l.format = FormatV1
+		return nil
+	}
+	l.format = FormatV2
+	header := make([]byte, v2HeaderSize)
+	if _, err := io.ReadFull(l.r, header); err != nil {
+		return &ErrCorruptLog{Offset: l.offset, Reason: "truncated v2 header", Cause: err}
+	}
+	l.offset += int64(v2HeaderSize)
+	return nil
+}
+
+// Next returns the next LogEvent in the stream. It returns io.EOF once
+// the stream is exhausted cleanly between records; an EOF encountered
+// partway through a record is reported as an *ErrCorruptLog instead,
+// since it indicates a truncated log file rather than a normal end of
+// input.
+func (l *LogEventReader) Next() (LogEvent, error) {
+	if err := l.detectFormat(); err != nil {
+		return LogEvent{}, err
+	}
+	if l.format == FormatV2 {
+		return l.readV2Record()
+	}
+	return l.readV1Record()
+}
+
+func (l *LogEventReader) readV1Record() (LogEvent, error) {
+	nameOffset := l.offset
+	name, err := l.r.ReadString('\x00')
+	if err != nil {
+		if err == io.EOF && len(name) == 0 {
+			return LogEvent{}, io.EOF
+		}
+		return LogEvent{}, &ErrCorruptLog{Offset: nameOffset, Reason: "truncated record name", Cause: err}
+	}
+	l.offset += int64(len(name))
+	name = name[:len(name)-1]
+
+	var kindBuf [LEN_TYPE_SYMBOL]byte
+	if _, err := io.ReadFull(l.r, kindBuf[:]); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record type", Cause: err}
+	}
+	l.offset += int64(len(kindBuf))
+
+	var timeNs int64
+	if err := binary.Read(l.r, binary.LittleEndian, &timeNs); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record timestamp", Cause: err}
+	}
+	l.offset += 8
+
+	return LogEvent{Name: name, Kind: kindBuf[0], TimeNs: timeNs, Format: FormatV1}, nil
+}
+
+func (l *LogEventReader) readV2Record() (LogEvent, error) {
+	startOffset := l.offset
+	nameLen, err := binary.ReadUvarint(l.r)
+	if err != nil {
+		if err == io.EOF {
+			return LogEvent{}, io.EOF
+		}
+		return LogEvent{}, &ErrCorruptLog{Offset: startOffset, Reason: "truncated record length", Cause: err}
+	}
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], nameLen)
+	l.offset += int64(n)
+
+	var record bytes.Buffer
+	record.Write(lenBuf[:n])
+
+	nameBytes := make([]byte, nameLen)
+	if _, err := io.ReadFull(l.r, nameBytes); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record name", Cause: err}
+	}
+	record.Write(nameBytes)
+	l.offset += int64(nameLen)
+
+	var kindBuf [1]byte
+	if _, err := io.ReadFull(l.r, kindBuf[:]); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record type", Cause: err}
+	}
+	record.WriteByte(kindBuf[0])
+	l.offset++
+
+	var wallNs, monoNs int64
+	if err := binary.Read(l.r, binary.LittleEndian, &wallNs); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record wall time", Cause: err}
+	}
+	binary.Write(&record, binary.LittleEndian, wallNs)
+	l.offset += 8
+
+	if err := binary.Read(l.r, binary.LittleEndian, &monoNs); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record mono time", Cause: err}
+	}
+	binary.Write(&record, binary.LittleEndian, monoNs)
+	l.offset += 8
+
+	var crc uint32
+	if err := binary.Read(l.r, binary.LittleEndian, &crc); err != nil {
+		return LogEvent{}, &ErrCorruptLog{Offset: l.offset, Reason: "truncated record checksum", Cause: err}
+	}
+	l.offset += 4
+
+	if want := crc32.Checksum(record.Bytes(), crc32cTable); want != crc {
+		return LogEvent{}, &ErrCorruptLog{Offset: startOffset, Reason: "checksum mismatch"}
+	}
+
+	return LogEvent{
+		Name:   string(nameBytes),
+		Kind:   kindBuf[0],
+		TimeNs: wallNs,
+		MonoNs: monoNs,
+		Format: FormatV2,
+	}, nil
+}
+
+// StreamParse calls fn once per LogEvent found across filenames, in
+// order, without ever loading a whole file into memory. It stops and
+// returns the first error returned either by reading a file or by fn.
+func StreamParse(filenames []string, fn func(LogEvent) error) error {
+	for _, fname := range filenames {
+		if err := streamParseFile(fname, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func streamParseFile(fname string, fn func(LogEvent) error) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return fmt.Errorf("attempted to parse file at invalid filepath %s: %w", fname, err)
+	}
+	defer f.Close()
+
+	reader := NewLogEventReader(f)
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package timers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTempLog writes a registry's log under format to a fresh temp
+// file and returns its path.
+func writeTempLog(t *testing.T, format Format, fn func(r *TimerRegistry)) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "timers.log")
+	r := NewTimerRegistry()
+	r.SetLogFile(path, format)
+	fn(r)
+	r.CloseLogFile()
+	return path
+}
+
+func TestStreamParseRoundTripV1(t *testing.T) {
+	path := writeTempLog(t, FormatV1, func(r *TimerRegistry) {
+		r.StartLogTimer("request")
+		r.EndLogTimer("request")
+		r.StartLogTimer("request")
+		r.EndLogTimer("request")
+	})
+
+	tmap, err := ParseFileToMapE([]string{path})
+	if err != nil {
+		t.Fatalf("ParseFileToMapE: %v", err)
+	}
+	summary, ok := tmap["request"]
+	if !ok {
+		t.Fatalf("tmap has no entry for %q", "request")
+	}
+	if len(summary.starts) != 2 || len(summary.ends) != 2 {
+		t.Fatalf("summary = %+v, want 2 starts and 2 ends", summary)
+	}
+
+	deltas := ParseMapToDeltas(tmap)["request"]
+	if len(deltas) != 2 {
+		t.Fatalf("ParseMapToDeltas returned %d deltas, want 2", len(deltas))
+	}
+	for _, d := range deltas {
+		if d < 0 {
+			t.Errorf("delta = %d, want >= 0", d)
+		}
+	}
+}
+
+func TestStreamParseTruncatedRecordIsCorruptLog(t *testing.T) {
+	path := writeTempLog(t, FormatV1, func(r *TimerRegistry) {
+		r.StartLogTimer("request")
+	})
+
+	// Truncate mid-timestamp so the reader hits EOF partway through a
+	// record instead of cleanly between records.
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseFileToMapE([]string{path})
+	var corrupt *ErrCorruptLog
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("ParseFileToMapE on truncated log = %v, want *ErrCorruptLog", err)
+	}
+}
@@ -0,0 +1,238 @@
+package timers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* SCOPED TIMERS
+
+A ScopeTree measures nested code regions, inspired by hierarchical
+profilers. Scope starts a named region, nesting it under whatever
+region is already active on ctx, and returns a context carrying the new
+region plus a closer that ends it. Nesting is tracked through ctx
+(rather than goroutine identity, which Go does not expose) so fan-out
+across goroutines works as long as the derived ctx is passed along:
+
+	ctx, done := timers.Scope(ctx, "request")
+	defer done()
+	ctx, done = timers.Scope(ctx, "decode")
+	...
+	done()
+
+Every region with the same name and the same ancestors aggregates into
+one ScopeNode, so repeated calls build up Count/Total/Self/percentile
+statistics rather than each being reported individually. */
+
+// ScopeStats summarizes every recorded call of a ScopeNode. Total and
+// Self are sums across all calls; Self is Total minus the Total of
+// every direct child call, i.e. time spent in this region excluding
+// time spent in nested regions. Min/Max/Mean/P50/P95/P99 are computed
+// over the per-call Total durations.
+type ScopeStats struct {
+	Count int
+	Total time.Duration
+	Self  time.Duration
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// ScopeNode is one position in the scope tree: a name plus its
+// children, keyed by the chain of Scope calls that led to it.
+type ScopeNode struct {
+	Name string
+
+	mu        sync.Mutex
+	children  map[string]*ScopeNode
+	durations []time.Duration
+	selfTotal time.Duration
+}
+
+func newScopeNode(name string) *ScopeNode {
+	return &ScopeNode{Name: name, children: make(map[string]*ScopeNode)}
+}
+
+func (n *ScopeNode) child(name string) *ScopeNode {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c, ok := n.children[name]
+	if !ok {
+		c = newScopeNode(name)
+		n.children[name] = c
+	}
+	return c
+}
+
+func (n *ScopeNode) sortedChildren() []*ScopeNode {
+	n.mu.Lock()
+	children := make([]*ScopeNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	n.mu.Unlock()
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children
+}
+
+func (n *ScopeNode) record(total, self time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.durations = append(n.durations, total)
+	n.selfTotal += self
+}
+
+// Stats computes the current ScopeStats for this node.
+func (n *ScopeNode) Stats() ScopeStats {
+	n.mu.Lock()
+	durations := append([]time.Duration(nil), n.durations...)
+	self := n.selfTotal
+	n.mu.Unlock()
+
+	if len(durations) == 0 {
+		return ScopeStats{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return ScopeStats{
+		Count: len(durations),
+		Total: total,
+		Self:  self,
+		Min:   durations[0],
+		Max:   durations[len(durations)-1],
+		Mean:  total / time.Duration(len(durations)),
+		P50:   percentile(durations, 0.50),
+		P95:   percentile(durations, 0.95),
+		P99:   percentile(durations, 0.99),
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// ScopeTree is the root of a scope aggregation tree. Each independent
+// ScopeTree keeps its own statistics, just as TimerRegistry lets
+// unrelated parts of a program keep independent timer sets.
+type ScopeTree struct {
+	root *ScopeNode
+}
+
+func NewScopeTree() *ScopeTree {
+	return &ScopeTree{root: newScopeNode("")}
+}
+
+// DefaultScopeTree backs the package-level Scope/ReportTree/ReportJSON
+// functions below.
+var DefaultScopeTree *ScopeTree = NewScopeTree()
+
+type scopeCtxKey struct{}
+
+type scopeFrame struct {
+	node  *ScopeNode
+	start time.Time
+
+	mu         sync.Mutex
+	childTotal time.Duration
+}
+
+// Scope starts a named, nested timing region as a child of whatever
+// region is active on ctx (or as a top-level region if none is). It
+// returns a context carrying the new region, and a closer that ends
+// it, typically called via defer; calls after the first are no-ops.
+func (t *ScopeTree) Scope(ctx context.Context, name string) (context.Context, func()) {
+	parent, _ := ctx.Value(scopeCtxKey{}).(*scopeFrame)
+	parentNode := t.root
+	if parent != nil {
+		parentNode = parent.node
+	}
+	frame := &scopeFrame{node: parentNode.child(name), start: time.Now()}
+	newCtx := context.WithValue(ctx, scopeCtxKey{}, frame)
+
+	var closeOnce sync.Once
+	closer := func() {
+		closeOnce.Do(func() {
+			total := time.Since(frame.start)
+			frame.mu.Lock()
+			childTotal := frame.childTotal
+			frame.mu.Unlock()
+			self := total - childTotal
+			if self < 0 {
+				self = 0
+			}
+			frame.node.record(total, self)
+			if parent != nil {
+				parent.mu.Lock()
+				parent.childTotal += total
+				parent.mu.Unlock()
+			}
+		})
+	}
+	return newCtx, closer
+}
+
+// ReportTree renders the aggregated scope tree as indented text, one
+// line per node, with its child nodes nested beneath it.
+func (t *ScopeTree) ReportTree(w io.Writer) error {
+	return writeScopeTree(w, t.root, 0)
+}
+
+func writeScopeTree(w io.Writer, node *ScopeNode, depth int) error {
+	for _, c := range node.sortedChildren() {
+		s := c.Stats()
+		_, err := fmt.Fprintf(w, "%s%s: count=%d total=%s self=%s mean=%s p50=%s p95=%s p99=%s\n",
+			strings.Repeat("  ", depth), c.Name, s.Count, s.Total, s.Self, s.Mean, s.P50, s.P95, s.P99)
+		if err != nil {
+			return err
+		}
+		if err := writeScopeTree(w, c, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scopeJSONNode struct {
+	Name     string           `json:"name"`
+	Stats    ScopeStats       `json:"stats"`
+	Children []*scopeJSONNode `json:"children,omitempty"`
+}
+
+func buildScopeJSON(node *ScopeNode) *scopeJSONNode {
+	out := &scopeJSONNode{Name: node.Name, Stats: node.Stats()}
+	for _, c := range node.sortedChildren() {
+		out.Children = append(out.Children, buildScopeJSON(c))
+	}
+	return out
+}
+
+// ReportJSON renders the aggregated scope tree as JSON, with the same
+// shape as ReportTree.
+func (t *ScopeTree) ReportJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(buildScopeJSON(t.root).Children)
+}
+
+func Scope(ctx context.Context, name string) (context.Context, func()) {
+	return DefaultScopeTree.Scope(ctx, name)
+}
+
+func ReportTree(w io.Writer) error {
+	return DefaultScopeTree.ReportTree(w)
+}
+
+func ReportJSON(w io.Writer) error {
+	return DefaultScopeTree.ReportJSON(w)
+}
@@ -0,0 +1,63 @@
+package timers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScopeTreeSelfExcludesChildren(t *testing.T) {
+	tree := NewScopeTree()
+	ctx := context.Background()
+
+	ctx, done := tree.Scope(ctx, "request")
+	childCtx, childDone := tree.Scope(ctx, "decode")
+	time.Sleep(5 * time.Millisecond)
+	childDone()
+	done()
+	_ = childCtx
+
+	root := tree.root.child("request")
+	decode := root.child("decode")
+
+	rootStats := root.Stats()
+	decodeStats := decode.Stats()
+
+	if decodeStats.Count != 1 {
+		t.Fatalf("decode.Count = %d, want 1", decodeStats.Count)
+	}
+	if rootStats.Total < decodeStats.Total {
+		t.Fatalf("request.Total (%s) < decode.Total (%s)", rootStats.Total, decodeStats.Total)
+	}
+	wantSelf := rootStats.Total - decodeStats.Total
+	if rootStats.Self != wantSelf {
+		t.Errorf("request.Self = %s, want Total - decode.Total = %s", rootStats.Self, wantSelf)
+	}
+}
+
+func TestScopeTreeAggregatesRepeatedCalls(t *testing.T) {
+	tree := NewScopeTree()
+	for i := 0; i < 3; i++ {
+		_, done := tree.Scope(context.Background(), "op")
+		done()
+	}
+
+	stats := tree.root.child("op").Stats()
+	if stats.Count != 3 {
+		t.Fatalf("Count = %d, want 3", stats.Count)
+	}
+	if stats.Min > stats.Mean || stats.Mean > stats.Max {
+		t.Errorf("expected Min <= Mean <= Max, got %s <= %s <= %s", stats.Min, stats.Mean, stats.Max)
+	}
+}
+
+func TestScopeClosesOnlyOnce(t *testing.T) {
+	tree := NewScopeTree()
+	_, done := tree.Scope(context.Background(), "op")
+	done()
+	done() // must be a no-op thanks to sync.Once, not a double-count
+
+	if stats := tree.root.child("op").Stats(); stats.Count != 1 {
+		t.Fatalf("Count = %d after calling done() twice, want 1", stats.Count)
+	}
+}
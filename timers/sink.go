@@ -0,0 +1,249 @@
+package timers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/* PLUGGABLE SINKS
+
+A Sink records the start and end of named timers wherever it chooses
+to; FileSink, LogFileSink, and BufferSink package up the three
+built-in behaviors from timers.go this way, so callers can compose or
+replace them (MultiSink, OTelSink) without the start/end call sites
+caring which is active. SetSink swaps the sink that the package-level
+RecordStart/RecordEnd functions record to. The t passed to RecordStart/RecordEnd is authoritative for sinks
+that can use it (OTelSink does); FileSink, LogFileSink, and BufferSink
+wrap storage that has always timestamped with its own time.Now() and
+continue to do so. */
+
+type Sink interface {
+	RecordStart(name string, t time.Time) error
+	RecordEnd(name string, t time.Time) error
+	Flush() error
+	Close() error
+}
+
+// sinkBox lets defaultSink store any concrete Sink behind a single
+// consistent dynamic type, since atomic.Value panics if successive
+// Store calls don't agree on one.
+type sinkBox struct {
+	sink Sink
+}
+
+var defaultSink atomic.Value // holds *sinkBox
+
+func init() {
+	defaultSink.Store(&sinkBox{sink: NewBufferSink(DefaultLogBuffer)})
+}
+
+// SetSink replaces the sink backing the package-level RecordStart and
+// RecordEnd functions below. The older package-level functions
+// (StartTimer, StartFileTimer, StartLogTimer, StartBufferedLogTimer and
+// their End counterparts) do not go through the sink: they keep using
+// DefaultRegistry, the collection set by SetFileTimerCollection, and
+// DefaultLogBuffer regardless of which sink is installed.
+func SetSink(s Sink) {
+	defaultSink.Store(&sinkBox{sink: s})
+}
+
+func RecordStart(name string) error {
+	return defaultSink.Load().(*sinkBox).sink.RecordStart(name, time.Now())
+}
+
+func RecordEnd(name string) error {
+	return defaultSink.Load().(*sinkBox).sink.RecordEnd(name, time.Now())
+}
+
+// FileSink records timers as one pair of files per name, via a
+// FileTimerCollection.
+type FileSink struct {
+	collection *FileTimerCollection
+}
+
+func NewFileSink(collection *FileTimerCollection) *FileSink {
+	return &FileSink{collection: collection}
+}
+
+func (s *FileSink) RecordStart(name string, t time.Time) error {
+	return s.collection.StartTimerE(name)
+}
+
+func (s *FileSink) RecordEnd(name string, t time.Time) error {
+	return s.collection.EndTimerE(name)
+}
+
+func (s *FileSink) Flush() error {
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	return nil
+}
+
+// LogFileSink records timers to a single on-disk log, via a
+// TimerRegistry's log file.
+type LogFileSink struct {
+	registry *TimerRegistry
+}
+
+func NewLogFileSink(registry *TimerRegistry) *LogFileSink {
+	return &LogFileSink{registry: registry}
+}
+
+func (s *LogFileSink) RecordStart(name string, t time.Time) error {
+	return s.registry.StartLogTimerE(name)
+}
+
+func (s *LogFileSink) RecordEnd(name string, t time.Time) error {
+	return s.registry.EndLogTimerE(name)
+}
+
+func (s *LogFileSink) Flush() error {
+	return s.registry.SyncLogFileE()
+}
+
+func (s *LogFileSink) Close() error {
+	return s.registry.CloseLogFileE()
+}
+
+// BufferSink records timers in memory, via a LogBuffer.
+type BufferSink struct {
+	buffer *LogBuffer
+}
+
+func NewBufferSink(buffer *LogBuffer) *BufferSink {
+	return &BufferSink{buffer: buffer}
+}
+
+func (s *BufferSink) RecordStart(name string, t time.Time) error {
+	s.buffer.StartTimer(name)
+	return nil
+}
+
+func (s *BufferSink) RecordEnd(name string, t time.Time) error {
+	s.buffer.EndTimer(name)
+	return nil
+}
+
+func (s *BufferSink) Flush() error {
+	return nil
+}
+
+func (s *BufferSink) Close() error {
+	return nil
+}
+
+// MultiSink fans RecordStart/RecordEnd/Flush/Close out to every Sink
+// it wraps, continuing on to the rest even if one returns an error,
+// and joining any errors it collected along the way.
+type MultiSink struct {
+	sinks []Sink
+}
+
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{sinks: sinks}
+}
+
+func (s *MultiSink) RecordStart(name string, t time.Time) error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.RecordStart(name, t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MultiSink) RecordEnd(name string, t time.Time) error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.RecordEnd(name, t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MultiSink) Flush() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MultiSink) Close() error {
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OTelSink converts each RecordStart/RecordEnd pair into one span on
+// tracer, named after the timer and timestamped from the recorded
+// times. Since a Sink only identifies a timer by name, concurrent
+// starts of the same name are matched to their ends in LIFO order via
+// a small per-name stack.
+type OTelSink struct {
+	tracer trace.Tracer
+
+	mu     sync.Mutex
+	active map[string][]trace.Span
+}
+
+func NewOTelSink(tracer trace.Tracer) *OTelSink {
+	return &OTelSink{tracer: tracer, active: make(map[string][]trace.Span)}
+}
+
+func (s *OTelSink) RecordStart(name string, t time.Time) error {
+	_, span := s.tracer.Start(context.Background(), name, trace.WithTimestamp(t))
+	s.mu.Lock()
+	s.active[name] = append(s.active[name], span)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *OTelSink) RecordEnd(name string, t time.Time) error {
+	s.mu.Lock()
+	stack := s.active[name]
+	if len(stack) == 0 {
+		s.mu.Unlock()
+		return &ErrTimerNotStarted{Name: name, Verb: "end"}
+	}
+	span := stack[len(stack)-1]
+	s.active[name] = stack[:len(stack)-1]
+	s.mu.Unlock()
+	span.End(trace.WithTimestamp(t))
+	return nil
+}
+
+func (s *OTelSink) Flush() error {
+	return nil
+}
+
+// Close ends every span still open because its RecordEnd never
+// arrived, so a timer left running when the sink is torn down is not
+// silently lost from the trace.
+func (s *OTelSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for name, stack := range s.active {
+		for _, span := range stack {
+			span.End(trace.WithTimestamp(now))
+		}
+		delete(s.active, name)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package timers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// fakeSink records every call made to it, for asserting MultiSink's
+// fan-out behavior.
+type fakeSink struct {
+	mu                 sync.Mutex
+	starts, ends       []string
+	flushes, closes    int
+	startErr, endErr   error
+	flushErr, closeErr error
+}
+
+func (s *fakeSink) RecordStart(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts = append(s.starts, name)
+	return s.startErr
+}
+
+func (s *fakeSink) RecordEnd(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ends = append(s.ends, name)
+	return s.endErr
+}
+
+func (s *fakeSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+	return s.flushErr
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closes++
+	return s.closeErr
+}
+
+func TestMultiSinkFansOutToEverySink(t *testing.T) {
+	a := &fakeSink{}
+	b := &fakeSink{}
+	m := NewMultiSink(a, b)
+
+	if err := m.RecordStart("req", time.Now()); err != nil {
+		t.Fatalf("RecordStart: %v", err)
+	}
+	if err := m.RecordEnd("req", time.Now()); err != nil {
+		t.Fatalf("RecordEnd: %v", err)
+	}
+	if err := m.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, s := range []*fakeSink{a, b} {
+		if len(s.starts) != 1 || len(s.ends) != 1 || s.flushes != 1 || s.closes != 1 {
+			t.Errorf("sink = %+v, want one of each call", s)
+		}
+	}
+}
+
+func TestMultiSinkContinuesPastErrorsAndJoinsThem(t *testing.T) {
+	errA := errors.New("sink a failed")
+	errB := errors.New("sink b failed")
+	a := &fakeSink{startErr: errA}
+	b := &fakeSink{startErr: errB}
+	m := NewMultiSink(a, b)
+
+	err := m.RecordStart("req", time.Now())
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("RecordStart joined error = %v, want it to wrap both %v and %v", err, errA, errB)
+	}
+	// Both sinks must still have observed the call despite a failing first.
+	if len(a.starts) != 1 || len(b.starts) != 1 {
+		t.Errorf("a.starts=%v b.starts=%v, want both sinks called", a.starts, b.starts)
+	}
+}
+
+// fakeTracer/fakeSpan record start/end calls so OTelSink's LIFO
+// stack-matching behavior can be asserted without a full OTel SDK.
+type fakeTracer struct {
+	noop.Tracer
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (f *fakeTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &fakeSpan{name: name}
+	f.mu.Lock()
+	f.spans = append(f.spans, span)
+	f.mu.Unlock()
+	return ctx, span
+}
+
+type fakeSpan struct {
+	noop.Span
+	name  string
+	ended bool
+}
+
+func (s *fakeSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
+
+func TestOTelSinkMatchesStartEndLIFO(t *testing.T) {
+	tracer := &fakeTracer{}
+	s := NewOTelSink(tracer)
+	now := time.Now()
+
+	if err := s.RecordStart("outer", now); err != nil {
+		t.Fatalf("RecordStart(outer): %v", err)
+	}
+	if err := s.RecordStart("outer", now); err != nil {
+		t.Fatalf("RecordStart(outer) nested: %v", err)
+	}
+	if err := s.RecordEnd("outer", now); err != nil {
+		t.Fatalf("RecordEnd(outer): %v", err)
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(tracer.spans))
+	}
+	if !tracer.spans[1].ended {
+		t.Errorf("second (innermost) span not ended by first RecordEnd")
+	}
+	if tracer.spans[0].ended {
+		t.Errorf("first span ended prematurely; LIFO matching should end the most recent start first")
+	}
+
+	var notStarted *ErrTimerNotStarted
+	if err := s.RecordEnd("never-started", now); !errors.As(err, &notStarted) {
+		t.Errorf("RecordEnd on unstarted timer = %v, want *ErrTimerNotStarted", err)
+	}
+}
+
+func TestFileSinkDelegatesToCollection(t *testing.T) {
+	collection := NewFileTimerCollection(t.TempDir())
+	s := NewFileSink(collection)
+
+	if err := s.RecordStart("req", time.Now()); err != nil {
+		t.Fatalf("RecordStart: %v", err)
+	}
+	if err := s.RecordEnd("req", time.Now()); err != nil {
+		t.Fatalf("RecordEnd: %v", err)
+	}
+	if delta, err := collection.GetDeltaE("req"); err != nil {
+		t.Fatalf("collection.GetDeltaE after sink RecordStart/RecordEnd: %v", err)
+	} else if delta < 0 {
+		t.Errorf("delta = %d, want >= 0", delta)
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Errorf("Flush: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// A name whose expanded path can't be created (its parent directory
+	// doesn't exist) must have the collection's error propagate back
+	// through the sink rather than being swallowed.
+	var pathErr *os.PathError
+	if err := s.RecordStart("missing/dir", time.Now()); !errors.As(err, &pathErr) {
+		t.Errorf("RecordStart with unwritable path = %v, want wrapped *os.PathError", err)
+	}
+}
+
+func TestLogFileSinkAppendsSyncsCloses(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/timers.log"
+	registry := NewTimerRegistry()
+	if err := registry.SetLogFileE(path, FormatV1); err != nil {
+		t.Fatalf("SetLogFileE: %v", err)
+	}
+	s := NewLogFileSink(registry)
+
+	if err := s.RecordStart("req", time.Now()); err != nil {
+		t.Fatalf("RecordStart: %v", err)
+	}
+	if err := s.RecordEnd("req", time.Now()); err != nil {
+		t.Fatalf("RecordEnd: %v", err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat(%s): %v", path, err)
+	}
+	if fi.Size() == 0 {
+		t.Errorf("log file is empty after RecordStart/RecordEnd/Flush, want appended records")
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// Once closed, the registry no longer has a log file; further writes
+	// must fail rather than silently reopening or panicking.
+	if err := s.RecordStart("req2", time.Now()); !errors.Is(err, ErrNoLogFile) {
+		t.Errorf("RecordStart after Close = %v, want ErrNoLogFile", err)
+	}
+}
+
+// TestSetSinkRoutesPackageLevelRecordCalls covers request #6's
+// package-level sugar: SetSink must redirect RecordStart/RecordEnd to
+// whatever sink was installed, the same way SetFileTimerCollection
+// redirects the file-timer package funcs.
+func TestSetSinkRoutesPackageLevelRecordCalls(t *testing.T) {
+	old := defaultSink.Load()
+	defer defaultSink.Store(old)
+
+	fake := &fakeSink{}
+	SetSink(fake)
+
+	if err := RecordStart("req"); err != nil {
+		t.Fatalf("RecordStart: %v", err)
+	}
+	if err := RecordEnd("req"); err != nil {
+		t.Fatalf("RecordEnd: %v", err)
+	}
+	if len(fake.starts) != 1 || len(fake.ends) != 1 {
+		t.Errorf("fake sink saw starts=%v ends=%v, want one RecordStart and one RecordEnd routed to it", fake.starts, fake.ends)
+	}
+}
+
+func TestOTelSinkCloseEndsDanglingSpans(t *testing.T) {
+	tracer := &fakeTracer{}
+	s := NewOTelSink(tracer)
+	if err := s.RecordStart("leaked", time.Now()); err != nil {
+		t.Fatalf("RecordStart: %v", err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !tracer.spans[0].ended {
+		t.Errorf("Close did not end the still-open span")
+	}
+}
@@ -1,39 +1,79 @@
 package timers
 
 import (
-	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
-	)
+)
 
-/* HASHTABLE-BASED TIMERS */
+/* HASHTABLE-BASED TIMERS
 
-var timers map[string]int64 = make(map[string]int64)
-var timersEnd map[string]int64 = make(map[string]int64)
+A TimerRegistry tracks named start/end timestamps in memory. Unlike the
+package-level functions below (which are kept only for backwards
+compatibility), a *TimerRegistry can be constructed independently, so
+unrelated parts of a program (or unrelated tests running in parallel)
+can each keep their own set of timers without colliding. */
 
-func StartTimer(name string) {
-	if _, ok := timers[name]; ok {
-		panic(fmt.Sprintf("Attempted to start running timer %s", name))
-	} else {
-		timers[name] = time.Now().UnixNano()
+type TimerRegistry struct {
+	mu        sync.RWMutex
+	timers    map[string]int64
+	timersEnd map[string]int64
+	file      *os.File
+	format    Format
+	logStart  time.Time // monotonic reference sampled at SetLogFile time; only meaningful for FormatV2
+}
+
+func NewTimerRegistry() *TimerRegistry {
+	return &TimerRegistry{
+		timers:    make(map[string]int64),
+		timersEnd: make(map[string]int64),
 	}
 }
 
-func EndTimer(name string) {
-	if _, ok := timersEnd[name]; ok {
-		panic(fmt.Sprintf("Attempted to end stopped timer %s", name))
-	} else {
-		timersEnd[name] = time.Now().UnixNano()
+// DefaultRegistry backs the package-level timer functions below.
+var DefaultRegistry *TimerRegistry = NewTimerRegistry()
+
+func (r *TimerRegistry) StartTimerE(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.timers[name]; ok {
+		return &ErrTimerAlreadyRunning{Name: name}
 	}
+	r.timers[name] = time.Now().UnixNano()
+	return nil
 }
 
-func GetTimerDelta(name string) int64 {
-	if valStart, ok := timers[name]; ok {
-		if valEnd, ok := timersEnd[name]; ok {
+func (r *TimerRegistry) StartTimer(name string) {
+	if err := r.StartTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (r *TimerRegistry) EndTimerE(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.timersEnd[name]; ok {
+		return &ErrTimerAlreadyEnded{Name: name}
+	}
+	r.timersEnd[name] = time.Now().UnixNano()
+	return nil
+}
+
+func (r *TimerRegistry) EndTimer(name string) {
+	if err := r.EndTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (r *TimerRegistry) GetTimerDelta(name string) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if valStart, ok := r.timers[name]; ok {
+		if valEnd, ok := r.timersEnd[name]; ok {
 			return valEnd - valStart
 		} else {
 			return -2
@@ -43,256 +83,564 @@ func GetTimerDelta(name string) int64 {
 	}
 }
 
-func ResetTimer(name string) int64 {
-	if val, ok := timers[name]; ok {
-		now := time.Now().UnixNano()
-		timers[name] = now
-		return now - val
-	} else {
-		panic(fmt.Sprintf("Attempted to reset timer %s, which is not running", name))
+func (r *TimerRegistry) ResetTimerE(name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.timers[name]
+	if !ok {
+		return 0, &ErrTimerNotStarted{Name: name, Verb: "reset"}
 	}
+	now := time.Now().UnixNano()
+	r.timers[name] = now
+	return now - val, nil
 }
 
-func PollTimer(name string) int64 {
-	if val, ok := timers[name]; ok {
-		return time.Now().UnixNano() - val
-	} else {
-		panic(fmt.Sprintf("Attempted to poll timer %s, which is not running", name))
+func (r *TimerRegistry) ResetTimer(name string) int64 {
+	delta, err := r.ResetTimerE(name)
+	if err != nil {
+		panic(err)
 	}
+	return delta
 }
 
-func DeleteTimer(name string) {
-	if _, ok := timers[name]; ok {
-		delete(timers, name)
-	} else {
-		panic(fmt.Sprintf("Attempted to stop timer %s, which is not running", name))
+func (r *TimerRegistry) PollTimerE(name string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	val, ok := r.timers[name]
+	if !ok {
+		return 0, &ErrTimerNotStarted{Name: name, Verb: "poll"}
+	}
+	return time.Now().UnixNano() - val, nil
+}
+
+func (r *TimerRegistry) PollTimer(name string) int64 {
+	delta, err := r.PollTimerE(name)
+	if err != nil {
+		panic(err)
+	}
+	return delta
+}
+
+func (r *TimerRegistry) DeleteTimerE(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.timers[name]; !ok {
+		return &ErrTimerNotStarted{Name: name, Verb: "stop"}
 	}
-	delete(timersEnd, name)
+	delete(r.timers, name)
+	delete(r.timersEnd, name)
+	return nil
+}
+
+func (r *TimerRegistry) DeleteTimer(name string) {
+	if err := r.DeleteTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func StartTimer(name string) {
+	DefaultRegistry.StartTimer(name)
+}
+
+func StartTimerE(name string) error {
+	return DefaultRegistry.StartTimerE(name)
+}
+
+func EndTimer(name string) {
+	DefaultRegistry.EndTimer(name)
+}
+
+func EndTimerE(name string) error {
+	return DefaultRegistry.EndTimerE(name)
+}
+
+func GetTimerDelta(name string) int64 {
+	return DefaultRegistry.GetTimerDelta(name)
+}
+
+func ResetTimer(name string) int64 {
+	return DefaultRegistry.ResetTimer(name)
+}
+
+func ResetTimerE(name string) (int64, error) {
+	return DefaultRegistry.ResetTimerE(name)
+}
+
+func PollTimer(name string) int64 {
+	return DefaultRegistry.PollTimer(name)
+}
+
+func PollTimerE(name string) (int64, error) {
+	return DefaultRegistry.PollTimerE(name)
+}
+
+func DeleteTimer(name string) {
+	DefaultRegistry.DeleteTimer(name)
+}
+
+func DeleteTimerE(name string) error {
+	return DefaultRegistry.DeleteTimerE(name)
 }
 
-/* FILE-BASED TIMERS */
+/* FILE-BASED TIMERS
 
-var timerDir string
+A FileTimerCollection persists timer start/end times as files in a
+directory, so they survive across process restarts. Each collection is
+independent, so a single process can maintain more than one. */
 
-func SetFileTimerCollection (dirString string) {
+type FileTimerCollection struct {
+	dir string
+}
+
+func NewFileTimerCollectionE(dirString string) (*FileTimerCollection, error) {
 	fi, err := os.Stat(dirString)
-	if err == nil && fi.IsDir() {
-		lastIndex := len(dirString) - 1
-		if dirString[lastIndex] == '/' {
-			timerDir = dirString[0:lastIndex]
-		} else {
-			timerDir = dirString
-		}
-	} else {
-		panic(fmt.Sprintf("Attempted to set Timer collection to invalid directory %s", dirString))
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, &ErrInvalidTimerDir{Path: dirString}
 	}
+	dir := dirString
+	lastIndex := len(dir) - 1
+	if dir[lastIndex] == '/' {
+		dir = dir[0:lastIndex]
+	}
+	return &FileTimerCollection{dir: dir}, nil
 }
 
-func expandFilePathStart(name string) string {
-	return fmt.Sprintf("%s/%s_start", timerDir, name)
+func NewFileTimerCollection(dirString string) *FileTimerCollection {
+	c, err := NewFileTimerCollectionE(dirString)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// fileTimerCollectionBox lets defaultFileTimerCollection store a
+// possibly-nil *FileTimerCollection behind a single consistent dynamic
+// type, since atomic.Value panics if successive Store calls don't
+// agree on one.
+type fileTimerCollectionBox struct {
+	collection *FileTimerCollection
+}
+
+var defaultFileTimerCollection atomic.Value // holds *fileTimerCollectionBox
+
+func init() {
+	defaultFileTimerCollection.Store(&fileTimerCollectionBox{})
 }
 
-func expandFilePathEnd(name string) string {
-	return fmt.Sprintf("%s/%s_end", timerDir, name)
+func loadDefaultFileTimerCollection() *FileTimerCollection {
+	return defaultFileTimerCollection.Load().(*fileTimerCollectionBox).collection
 }
 
-/** This will overwrite any existing timers. I didn't add error checking here
+func SetFileTimerCollectionE(dirString string) error {
+	c, err := NewFileTimerCollectionE(dirString)
+	if err != nil {
+		return err
+	}
+	defaultFileTimerCollection.Store(&fileTimerCollectionBox{collection: c})
+	return nil
+}
+
+func SetFileTimerCollection(dirString string) {
+	if err := SetFileTimerCollectionE(dirString); err != nil {
+		panic(err)
+	}
+}
+
+func (c *FileTimerCollection) expandFilePathStart(name string) string {
+	return fmt.Sprintf("%s/%s_start", c.dir, name)
+}
+
+func (c *FileTimerCollection) expandFilePathEnd(name string) string {
+	return fmt.Sprintf("%s/%s_end", c.dir, name)
+}
+
+/*
+  - This will overwrite any existing timers. I didn't add error checking here
     because I reasoned that we may see some of the same timers from previous
-    runs of the program. */
-func StartFileTimer(name string) {
-	writeFileTimer(name, expandFilePathStart)
+    runs of the program.
+*/
+func (c *FileTimerCollection) StartTimerE(name string) error {
+	return c.writeFileTimerE(name, c.expandFilePathStart)
 }
 
-func EndFileTimer(name string) {
-	writeFileTimer(name, expandFilePathEnd)
+func (c *FileTimerCollection) StartTimer(name string) {
+	if err := c.StartTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (c *FileTimerCollection) EndTimerE(name string) error {
+	return c.writeFileTimerE(name, c.expandFilePathEnd)
 }
 
-func writeFileTimer(name string, nameFinder func (string) string) {
+func (c *FileTimerCollection) EndTimer(name string) {
+	if err := c.EndTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (c *FileTimerCollection) writeFileTimerE(name string, nameFinder func(string) string) error {
 	file, err := os.Create(nameFinder(name))
+	if err != nil {
+		return fmt.Errorf("could not write to file timer %s: %w", nameFinder(name), err)
+	}
 	defer file.Close()
-	if err == nil {
-		err = binary.Write(file, binary.LittleEndian, time.Now().UnixNano())
-		if err != nil {
-			panic(fmt.Sprintf("Could not write to file timer %s: %v", nameFinder(name), err))
-		}
-	} else {
-		panic(fmt.Sprintf("Could not write to file timer %s: %v", nameFinder(name), err))
+	if err = binary.Write(file, binary.LittleEndian, time.Now().UnixNano()); err != nil {
+		return fmt.Errorf("could not write to file timer %s: %w", nameFinder(name), err)
 	}
+	return nil
 }
 
-func readFileTimer(name string, nameFinder func (string) string) int64 {
+func (c *FileTimerCollection) readFileTimerE(name string, nameFinder func(string) string) (int64, error) {
 	file, err := os.Open(nameFinder(name))
+	if err != nil {
+		return 0, fmt.Errorf("could not open file timer %s: %w", nameFinder(name), err)
+	}
 	defer file.Close()
 	var fileTime int64
-	if err == nil {
-		err = binary.Read(file, binary.LittleEndian, &fileTime)
-		if err != nil {
-			panic(fmt.Sprintf("Could not poll file timer %s: %v", nameFinder(name), err))
-		}
-		return fileTime
-	} else {
-		panic(fmt.Sprintf("Could not open file timer %s: %v", nameFinder(name), err))
+	if err = binary.Read(file, binary.LittleEndian, &fileTime); err != nil {
+		return 0, fmt.Errorf("could not poll file timer %s: %w", nameFinder(name), err)
+	}
+	return fileTime, nil
+}
+
+func (c *FileTimerCollection) readFileTimer(name string, nameFinder func(string) string) int64 {
+	t, err := c.readFileTimerE(name, nameFinder)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// GetDeltaE returns the delta between a timer's start and end times, or
+// an error identifying which of them could not be read.
+func (c *FileTimerCollection) GetDeltaE(name string) (int64, error) {
+	startTime, err := c.readFileTimerE(name, c.expandFilePathStart)
+	if err != nil {
+		return 0, err
+	}
+	endTime, err := c.readFileTimerE(name, c.expandFilePathEnd)
+	if err != nil {
+		return 0, err
 	}
+	return endTime - startTime, nil
 }
 
-func GetFileTimerDelta(name string) (delta int64) {
+func (c *FileTimerCollection) GetDelta(name string) (delta int64) {
 	var started bool = false
-	defer func () {
-			if r := recover(); r != nil {
-				if started {
-					delta = -2 // indicates timer was started but never ended
-				} else {
-				 	delta = -1 // indicates timer was never started
-				}
+	defer func() {
+		if r := recover(); r != nil {
+			if started {
+				delta = -2 // indicates timer was started but never ended
+			} else {
+				delta = -1 // indicates timer was never started
 			}
-		}()
-	var startTime int64 = readFileTimer(name, expandFilePathStart)
+		}
+	}()
+	var startTime int64 = c.readFileTimer(name, c.expandFilePathStart)
 	started = true
-	var endTime int64 = readFileTimer(name, expandFilePathEnd)
+	var endTime int64 = c.readFileTimer(name, c.expandFilePathEnd)
 	delta = endTime - startTime
 	return
 }
 
+func (c *FileTimerCollection) PollE(name string) (int64, error) {
+	startTime, err := c.readFileTimerE(name, c.expandFilePathStart)
+	if err != nil {
+		return 0, err
+	}
+	return time.Now().UnixNano() - startTime, nil
+}
+
+func (c *FileTimerCollection) Poll(name string) int64 {
+	return time.Now().UnixNano() - c.readFileTimer(name, c.expandFilePathStart)
+}
+
+func (c *FileTimerCollection) DeleteE(name string) error {
+	if err := os.Remove(c.expandFilePathStart(name)); err != nil {
+		return fmt.Errorf("could not stop file timer %s: %w", name, err)
+	}
+	os.Remove(c.expandFilePathEnd(name))
+	return nil
+}
+
+func (c *FileTimerCollection) Delete(name string) {
+	if err := c.DeleteE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (c *FileTimerCollection) DeleteIfExists(name string) {
+	os.Remove(c.expandFilePathStart(name))
+	os.Remove(c.expandFilePathEnd(name))
+}
+
+func StartFileTimer(name string) {
+	if err := StartFileTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func StartFileTimerE(name string) error {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return ErrNoFileTimerCollection
+	}
+	return c.StartTimerE(name)
+}
+
+func EndFileTimer(name string) {
+	if err := EndFileTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func EndFileTimerE(name string) error {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return ErrNoFileTimerCollection
+	}
+	return c.EndTimerE(name)
+}
+
+func GetFileTimerDelta(name string) int64 {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		panic(ErrNoFileTimerCollection)
+	}
+	return c.GetDelta(name)
+}
+
+func GetFileTimerDeltaE(name string) (int64, error) {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return 0, ErrNoFileTimerCollection
+	}
+	return c.GetDeltaE(name)
+}
+
 func PollFileTimer(name string) int64 {
-	return time.Now().UnixNano() - readFileTimer(name, expandFilePathStart)
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		panic(ErrNoFileTimerCollection)
+	}
+	return c.Poll(name)
+}
+
+func PollFileTimerE(name string) (int64, error) {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return 0, ErrNoFileTimerCollection
+	}
+	return c.PollE(name)
 }
 
 func DeleteFileTimer(name string) {
-	var err error = os.Remove(expandFilePathStart(name))
-	if err != nil {
-		panic(fmt.Sprintf("Could not stop file timer %s: %v", name, err))
+	if err := DeleteFileTimerE(name); err != nil {
+		panic(err)
 	}
-	os.Remove(expandFilePathEnd(name))
+}
+
+func DeleteFileTimerE(name string) error {
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return ErrNoFileTimerCollection
+	}
+	return c.DeleteE(name)
 }
 
 func DeleteFileTimerIfExists(name string) {
-	os.Remove(expandFilePathStart(name))
-	os.Remove(expandFilePathEnd(name))
+	c := loadDefaultFileTimerCollection()
+	if c == nil {
+		return
+	}
+	c.DeleteIfExists(name)
 }
 
-/* LOG-BASED TIMERS */
+/* LOG-BASED TIMERS
 
-var file *os.File = nil
+Log timers append start/end events for a TimerRegistry to a single
+on-disk file, rather than keeping them in memory. SetLogFile picks which
+on-disk Format to write: FormatV1 is the original bare record stream,
+kept for compatibility with old log files and tools; FormatV2 adds a
+header, per-record CRCs, and a monotonic timestamp alongside the
+wall-clock one. */
 
-func SetLogFile(filepath string) {
-	if file != nil {
-		file.Close()
+func (r *TimerRegistry) SetLogFileE(filepath string, format Format) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
 	}
 	var err error
-	file, err = os.Create(filepath)
+	r.file, err = os.Create(filepath)
 	if err != nil {
-		panic(fmt.Sprintf("Attempted to set log to invalid filepath %v", err))
+		return fmt.Errorf("attempted to set log to invalid filepath: %w", err)
 	}
+	r.format = format
+	r.logStart = time.Now()
+	if format == FormatV2 {
+		if err := writeV2Header(r.file); err != nil {
+			return fmt.Errorf("failed to write log header: %w", err)
+		}
+	}
+	return nil
 }
 
-func CloseLogFile() {
-	if file == nil {
-		panic(fmt.Sprintf("Attempted to close log file, but not log file is active"))
-	} else {
-		file.Sync()
-		file.Close()
-		file = nil
+func (r *TimerRegistry) SetLogFile(filepath string, format Format) {
+	if err := r.SetLogFileE(filepath, format); err != nil {
+		panic(err)
 	}
 }
 
-func logEvent(name string, tag string) {
-	_, err := file.WriteString(fmt.Sprintf("%s\x00%s", name, tag))
-	if err == nil {
-		err = binary.Write(file, binary.LittleEndian, time.Now().UnixNano())
-		if err != nil {
-			panic(fmt.Sprintf("Failed to write current time to file: %v", err))
+func (r *TimerRegistry) CloseLogFileE() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ErrNoLogFile
+	}
+	r.file.Sync()
+	r.file.Close()
+	r.file = nil
+	return nil
+}
+
+// SyncLogFileE flushes the current log file to stable storage without
+// closing it, so a Sink can offer a Flush distinct from Close.
+func (r *TimerRegistry) SyncLogFileE() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ErrNoLogFile
+	}
+	return r.file.Sync()
+}
+
+func (r *TimerRegistry) CloseLogFile() {
+	if err := r.CloseLogFileE(); err != nil {
+		panic(err)
+	}
+}
+
+func (r *TimerRegistry) logEventE(name string, kind byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ErrNoLogFile
+	}
+	if r.format == FormatV2 {
+		if err := writeV2Record(r.file, name, kind, r.logStart); err != nil {
+			return fmt.Errorf("failed to write v2 record to file: %w", err)
 		}
-	} else {
-		panic(fmt.Sprintf("Failed to write timer name to file: %v", err))
-	}	
+		return nil
+	}
+	_, err := r.file.WriteString(fmt.Sprintf("%s\x00%c", name, kind))
+	if err != nil {
+		return fmt.Errorf("failed to write timer name to file: %w", err)
+	}
+	if err = binary.Write(r.file, binary.LittleEndian, time.Now().UnixNano()); err != nil {
+		return fmt.Errorf("failed to write current time to file: %w", err)
+	}
+	return nil
 }
 
 const (
-	START_SYMBOL string = "s"
-	END_SYMBOL string = "e"
-	LEN_TYPE_SYMBOL int = 1 // both START_SYMBOL and END_SYMBOL have this length
-	)
+	START_SYMBOL    string = "s"
+	END_SYMBOL      string = "e"
+	LEN_TYPE_SYMBOL int    = 1 // both START_SYMBOL and END_SYMBOL have this length
+)
 
 /** Name can't contain \0. */
+func (r *TimerRegistry) StartLogTimerE(name string) error {
+	return r.logEventE(name, START_SYMBOL[0])
+}
+
+func (r *TimerRegistry) StartLogTimer(name string) {
+	if err := r.StartLogTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func (r *TimerRegistry) EndLogTimerE(name string) error {
+	return r.logEventE(name, END_SYMBOL[0])
+}
+
+func (r *TimerRegistry) EndLogTimer(name string) {
+	if err := r.EndLogTimerE(name); err != nil {
+		panic(err)
+	}
+}
+
+func SetLogFile(filepath string, format Format) {
+	DefaultRegistry.SetLogFile(filepath, format)
+}
+
+func SetLogFileE(filepath string, format Format) error {
+	return DefaultRegistry.SetLogFileE(filepath, format)
+}
+
+func CloseLogFile() {
+	DefaultRegistry.CloseLogFile()
+}
+
+func CloseLogFileE() error {
+	return DefaultRegistry.CloseLogFileE()
+}
+
 func StartLogTimer(name string) {
-	logEvent(name, START_SYMBOL)
+	DefaultRegistry.StartLogTimer(name)
+}
+
+func StartLogTimerE(name string) error {
+	return DefaultRegistry.StartLogTimerE(name)
 }
 
 func EndLogTimer(name string) {
-	logEvent(name, END_SYMBOL)
+	DefaultRegistry.EndLogTimer(name)
+}
+
+func EndLogTimerE(name string) error {
+	return DefaultRegistry.EndLogTimerE(name)
 }
 
 type TimerSummary struct {
 	starts []int64
-	ends []int64
+	ends   []int64
 }
 
-func checkerr(f *os.File, filename string, err error) {
+func ParseFileToMap(filenames []string) map[string]*TimerSummary {
+	tmap, err := ParseFileToMapE(filenames)
 	if err != nil {
-		f.Close()
-		if err == io.EOF {
-			panic(fmt.Sprintf("Unexpected end of file when parsing %s", filename))
-		} else {
-			panic(fmt.Sprintf("Could not read file at filepath %s", filename))
-		}
+		panic(err)
 	}
+	return tmap
 }
 
-func ParseFileToMap(filenames []string) map[string]*TimerSummary {
-	var data [][]byte = make([][]byte, len(filenames))
-	for i := 0; i < len(filenames); i++ {
-		f, err := os.Open(filenames[i])
-		if err != nil {
-			f.Close()
-			panic(fmt.Sprintf("Attempted to parse file at invalid filepath %s", filenames[i]))
-		}
-		data[i], err = ioutil.ReadAll(f) // it's OK to buffer everything in memory since I'm constructing a hashtable out of it anyway
-		f.Close()
-		if err != nil {
-			panic(fmt.Sprintf("Could not read file at filepath %s", filenames[i]))
-		}
-	}
+// ParseFileToMapE parses filenames via StreamParse, so it never holds
+// more than one file's worth of buffering in memory even though the
+// resulting map does collect every event.
+func ParseFileToMapE(filenames []string) (map[string]*TimerSummary, error) {
 	var tmap map[string]*TimerSummary = make(map[string]*TimerSummary)
-	var buf []byte = make([]byte, LEN_TYPE_SYMBOL, LEN_TYPE_SYMBOL)
-	var name string
-	var frag2 string
-	var summary *TimerSummary
-	var ok bool
-	var time int64
-	var freader *bufio.Reader
-	var fname string
-	
-	for i := 0; i < len(filenames); i++ {
-		fname = filenames[i]
-		f, err := os.Open(fname)
-		if err != nil {
-			f.Close()
-			panic(fmt.Sprintf("Attempted to parse file at invalid filepath %s", fname))
+	err := StreamParse(filenames, func(event LogEvent) error {
+		summary, ok := tmap[event.Name]
+		if !ok {
+			summary = &TimerSummary{make([]int64, 0, 1), make([]int64, 0, 1)}
+			tmap[event.Name] = summary
 		}
-		freader = bufio.NewReader(f)
-		name, err = freader.ReadString('\x00')
-		for err != io.EOF {
-			name = name[:len(name) - 1]
-			_, err = freader.Read(buf)
-			checkerr(f, fname, err)
-			frag2 = string(buf)
-			err = binary.Read(freader, binary.LittleEndian, &time)
-			checkerr(f, fname, err)
-			summary, ok = tmap[name]
-			if !ok {
-				summary = &TimerSummary{make([]int64, 0, 1), make([]int64, 0, 1)}
-				tmap[name] = summary
-			}
-			if frag2 == START_SYMBOL {
-				summary.starts = append(summary.starts, time)
-			} else {
-				summary.ends = append(summary.ends, time)
-			}
-			name, err = freader.ReadString('\x00')
+		if event.Kind == START_SYMBOL[0] {
+			summary.starts = append(summary.starts, event.TimeNs)
+		} else {
+			summary.ends = append(summary.ends, event.TimeNs)
 		}
-		f.Close()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return tmap
+	return tmap, nil
 }
 
 func ParseMapToDeltas(tmap map[string]*TimerSummary) map[string][]int64 {
@@ -300,61 +648,78 @@ func ParseMapToDeltas(tmap map[string]*TimerSummary) map[string][]int64 {
 	var tsummary *TimerSummary
 	var deltamap map[string][]int64 = make(map[string][]int64)
 	var i int
-	
+
 	var deltas []int64
-	
-	TimerLoop:
-		for tname, tsummary = range tmap {
-			if len(tsummary.starts) == 0 {
-				fmt.Printf("Timer %s was ended but never started\n", tname)
-				continue
-			} else if len(tsummary.ends) == 0 {
-				fmt.Printf("Timer %s was started but never ended\n", tname)
-				continue
-			} else if len(tsummary.starts) != len(tsummary.ends) {
-				fmt.Printf("Timer %s has a different number of starts than ends\n", tname)
-				continue
+
+TimerLoop:
+	for tname, tsummary = range tmap {
+		if len(tsummary.starts) == 0 {
+			fmt.Printf("Timer %s was ended but never started\n", tname)
+			continue
+		} else if len(tsummary.ends) == 0 {
+			fmt.Printf("Timer %s was started but never ended\n", tname)
+			continue
+		} else if len(tsummary.starts) != len(tsummary.ends) {
+			fmt.Printf("Timer %s has a different number of starts than ends\n", tname)
+			continue
+		}
+		deltas = make([]int64, len(tsummary.starts))
+		for i = 0; i < len(tsummary.ends); i++ {
+			if tsummary.starts[i] > tsummary.ends[i] {
+				fmt.Printf("Timer %s has an end time preceding start time\n", tname)
+				continue TimerLoop
 			}
-			deltas = make([]int64, len(tsummary.starts))
-			for i = 0; i < len(tsummary.ends); i++ {
-				if tsummary.starts[i] > tsummary.ends[i] {
-					fmt.Printf("Timer %s has an end time preceding start time\n", tname)
-					continue TimerLoop
-				}
-				if i > 0 && tsummary.starts[i] < tsummary.ends[i - 1] {
-					fmt.Printf("Timer %s was started twice without being ended in between\n", tname)
-					continue TimerLoop
-				}
-				deltas[i] = tsummary.ends[i] - tsummary.starts[i]
+			if i > 0 && tsummary.starts[i] < tsummary.ends[i-1] {
+				fmt.Printf("Timer %s was started twice without being ended in between\n", tname)
+				continue TimerLoop
 			}
-			deltamap[tname] = deltas
+			deltas[i] = tsummary.ends[i] - tsummary.starts[i]
 		}
-		
+		deltamap[tname] = deltas
+	}
+
 	return deltamap
 }
 
-/* BUFFERED LOG TIMER 
-   An in-memory version of the log-based timer. Can be serialized to a log file. */
+/* BUFFERED LOG TIMER
+
+A LogBuffer is an in-memory version of the log-based timer. It can be
+serialized to a log file with Write. Each LogBuffer is independent, so
+unrelated parts of a program can accumulate separate buffers. */
+
+type LogBuffer struct {
+	mu     sync.RWMutex
+	timers map[string]*TimerSummary
+}
+
+func NewLogBuffer() *LogBuffer {
+	return &LogBuffer{timers: make(map[string]*TimerSummary)}
+}
 
-var bufferedTimers map[string]*TimerSummary = make(map[string]*TimerSummary)
+// DefaultLogBuffer backs the package-level buffered log timer functions below.
+var DefaultLogBuffer *LogBuffer = NewLogBuffer()
 
-func getSummary(name string) (summary *TimerSummary) {
+func (b *LogBuffer) getSummary(name string) (summary *TimerSummary) {
 	var exists bool
-	summary, exists = bufferedTimers[name]
+	summary, exists = b.timers[name]
 	if !exists {
 		summary = &TimerSummary{make([]int64, 0, 7), make([]int64, 0, 7)}
-		bufferedTimers[name] = summary
+		b.timers[name] = summary
 	}
 	return
 }
 
-func StartBufferedLogTimer(name string) {
-	var summary *TimerSummary = getSummary(name)
+func (b *LogBuffer) StartTimer(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var summary *TimerSummary = b.getSummary(name)
 	summary.starts = append(summary.starts, time.Now().UnixNano())
 }
 
-func EndBufferedLogTimer(name string) {
-	var summary *TimerSummary = getSummary(name)
+func (b *LogBuffer) EndTimer(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var summary *TimerSummary = b.getSummary(name)
 	summary.ends = append(summary.ends, time.Now().UnixNano())
 }
 
@@ -373,9 +738,11 @@ func writeArray(writer io.Writer, array []int64, name string, symbol string) err
 	return nil
 }
 
-func WriteLogBuffer(writer io.Writer) error {
+func (b *LogBuffer) Write(writer io.Writer) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	var err error
-	for name, summary := range bufferedTimers {
+	for name, summary := range b.timers {
 		err = writeArray(writer, summary.starts, name, START_SYMBOL)
 		if err != nil {
 			return err
@@ -388,14 +755,55 @@ func WriteLogBuffer(writer io.Writer) error {
 	return nil
 }
 
+// Get returns a snapshot of the buffered timers. The returned map and
+// the TimerSummary values it points to are copies, so callers can range
+// over them without synchronizing against concurrent StartTimer/EndTimer
+// calls on b.
+func (b *LogBuffer) Get() map[string]*TimerSummary {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	snapshot := make(map[string]*TimerSummary, len(b.timers))
+	for name, summary := range b.timers {
+		snapshot[name] = &TimerSummary{
+			starts: append([]int64(nil), summary.starts...),
+			ends:   append([]int64(nil), summary.ends...),
+		}
+	}
+	return snapshot
+}
+
+func (b *LogBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timers = make(map[string]*TimerSummary)
+}
+
+func (b *LogBuffer) Set(newbuffer map[string]*TimerSummary) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timers = newbuffer
+}
+
+func StartBufferedLogTimer(name string) {
+	DefaultLogBuffer.StartTimer(name)
+}
+
+func EndBufferedLogTimer(name string) {
+	DefaultLogBuffer.EndTimer(name)
+}
+
+func WriteLogBuffer(writer io.Writer) error {
+	return DefaultLogBuffer.Write(writer)
+}
+
 func GetLogBuffer() map[string]*TimerSummary {
-	return bufferedTimers
+	return DefaultLogBuffer.Get()
 }
 
 func ResetLogBuffer() {
-	bufferedTimers = make(map[string]*TimerSummary)
+	DefaultLogBuffer.Reset()
 }
 
 func SetLogBuffer(newbuffer map[string]*TimerSummary) {
-	bufferedTimers = newbuffer
+	DefaultLogBuffer.Set(newbuffer)
 }
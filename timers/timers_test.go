@@ -0,0 +1,162 @@
+package timers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestTimerRegistryConcurrent starts and ends many distinctly-named
+// timers on a single TimerRegistry from multiple goroutines at once.
+// Run with -race, it catches any access to the registry's maps that
+// isn't guarded by its mutex.
+func TestTimerRegistryConcurrent(t *testing.T) {
+	r := NewTimerRegistry()
+	var wg sync.WaitGroup
+	const n = 50
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := timerName(i)
+			if err := r.StartTimerE(name); err != nil {
+				t.Errorf("StartTimerE(%s): %v", name, err)
+			}
+			if err := r.EndTimerE(name); err != nil {
+				t.Errorf("EndTimerE(%s): %v", name, err)
+			}
+			if delta := r.GetTimerDelta(name); delta < 0 {
+				t.Errorf("GetTimerDelta(%s) = %d, want >= 0", name, delta)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestLogBufferGetIsSnapshot exercises the scenario the maintainer
+// reported under -race: one goroutine racing StartTimer/EndTimer while
+// another ranges over the map returned by Get. Get must return an
+// independent copy, or this test reports a data race.
+func TestLogBufferGetIsSnapshot(t *testing.T) {
+	b := NewLogBuffer()
+	b.StartTimer("warmup")
+	b.EndTimer("warmup")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			b.StartTimer("hot")
+			b.EndTimer("hot")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			for _, summary := range b.Get() {
+				_ = len(summary.starts)
+				_ = len(summary.ends)
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+// TestSetFileTimerCollectionConcurrent exercises the maintainer's
+// reported race: SetFileTimerCollection swapping
+// defaultFileTimerCollection concurrently with the package-level
+// file-timer wrappers reading it. Run with -race, it catches any
+// unsynchronized access to that default.
+func TestSetFileTimerCollectionConcurrent(t *testing.T) {
+	old := loadDefaultFileTimerCollection()
+	defer defaultFileTimerCollection.Store(&fileTimerCollectionBox{collection: old})
+
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := SetFileTimerCollectionE(dir); err != nil {
+				t.Errorf("SetFileTimerCollectionE: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = StartFileTimerE("race")
+			_ = EndFileTimerE("race")
+			_ = DeleteFileTimerE("race")
+		}
+	}()
+	wg.Wait()
+}
+
+// TestTimerRegistryResetAndDelete exercises ResetTimerE/ResetTimer and
+// DeleteTimerE/DeleteTimer, which otherwise share no test coverage with
+// the StartTimerE/EndTimerE pair they're modeled on.
+func TestTimerRegistryResetAndDelete(t *testing.T) {
+	r := NewTimerRegistry()
+
+	var notStarted *ErrTimerNotStarted
+	if _, err := r.ResetTimerE("never-started"); !errors.As(err, &notStarted) {
+		t.Fatalf("ResetTimerE on unstarted timer = %v, want *ErrTimerNotStarted", err)
+	}
+	if err := r.DeleteTimerE("never-started"); !errors.As(err, &notStarted) {
+		t.Fatalf("DeleteTimerE on unstarted timer = %v, want *ErrTimerNotStarted", err)
+	}
+
+	if err := r.StartTimerE("t"); err != nil {
+		t.Fatalf("StartTimerE: %v", err)
+	}
+	if delta, err := r.ResetTimerE("t"); err != nil {
+		t.Fatalf("ResetTimerE: %v", err)
+	} else if delta < 0 {
+		t.Errorf("ResetTimerE delta = %d, want >= 0", delta)
+	}
+	if _, err := r.PollTimerE("t"); err != nil {
+		t.Errorf("PollTimerE after reset: %v", err)
+	}
+
+	if err := r.DeleteTimerE("t"); err != nil {
+		t.Fatalf("DeleteTimerE: %v", err)
+	}
+	if _, err := r.PollTimerE("t"); !errors.As(err, &notStarted) {
+		t.Errorf("PollTimerE after delete = %v, want *ErrTimerNotStarted", err)
+	}
+
+	// ResetTimer/DeleteTimer panic instead of returning an error; the
+	// happy path must not panic, and the panic path must surface the
+	// same typed error as the E-variant.
+	r.StartTimer("p")
+	if delta := r.ResetTimer("p"); delta < 0 {
+		t.Errorf("ResetTimer delta = %d, want >= 0", delta)
+	}
+	r.DeleteTimer("p")
+	assertPanicsAsNotStarted(t, "ResetTimer", func() { r.ResetTimer("p") })
+	assertPanicsAsNotStarted(t, "DeleteTimer", func() { r.DeleteTimer("p") })
+}
+
+func assertPanicsAsNotStarted(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("%s: expected panic, got none", name)
+			return
+		}
+		err, ok := r.(error)
+		var notStarted *ErrTimerNotStarted
+		if !ok || !errors.As(err, &notStarted) {
+			t.Errorf("%s: panicked with %v, want *ErrTimerNotStarted", name, r)
+		}
+	}()
+	fn()
+}
+
+func timerName(i int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	return "timer-" + string(alphabet[i%len(alphabet)]) + string(alphabet[(i/len(alphabet))%len(alphabet)])
+}